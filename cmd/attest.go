@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"chalmers/tkey-group22/client/internal/tkey"
+)
+
+type attestResponse struct {
+	AppName  string         `json:"appName"`
+	Digest   string         `json:"digest"`
+	Manifest *tkey.Manifest `json:"manifest,omitempty"`
+	UDI      string         `json:"udi,omitempty"`
+}
+
+// attestHandler reports which firmware app produced a signature and how we
+// know it's the real thing, so a relying party can decide whether to trust
+// the TKey's pubkey before accepting it.
+func attestHandler(w http.ResponseWriter, r *http.Request) {
+	resp := attestResponse{
+		AppName: tkey.GetEmbeddedAppName(),
+		Digest:  tkey.GetEmbeddedAppDigest(),
+	}
+
+	if manifest, ok := tkey.GetVerifiedManifest(); ok {
+		resp.Manifest = manifest
+	}
+
+	if udi, err := tkey.GetUDI(); err == nil {
+		resp.UDI = udi
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
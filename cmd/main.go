@@ -4,15 +4,54 @@
 package main
 
 import (
+	"chalmers/tkey-group22/client/config"
 	"chalmers/tkey-group22/client/internal/auth"
+	"chalmers/tkey-group22/client/internal/authn"
 	. "chalmers/tkey-group22/client/internal/structs"
+	"chalmers/tkey-group22/client/internal/tkey"
+	"chalmers/tkey-group22/client/internal/transport"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"io"
 	"net/http"
 )
 
+// oauthConfiguredProvider is the provider name accepted by
+// /api/oauth/{provider}/login, set from the loaded config.
+var oauthConfiguredProvider string
+
 func main() {
+	flag.StringVar(&savePrefix, "save-prefix", "", "if set, write issued SSH certificates to <prefix>-cert.pub")
+	configPath := flag.String("config", "", "path to a JSON or TOML config file (see client/config) describing the backend and OAuth2/OIDC provider")
+	flag.Parse()
+
+	if err := tkey.VerifyEmbeddedApp(context.Background()); err != nil {
+		fmt.Printf("Refusing to start: embedded signer app failed verification: %s\n", err)
+		return
+	}
+
+	if *configPath == "" {
+		fmt.Println("Missing required -config flag")
+		return
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Printf("Failed to load config: %s\n", err)
+		return
+	}
+
+	auth.SetBackend(transport.NewHTTPBackend(cfg.BackendURL, cfg.BackendTLSPins))
+
+	a, err := authn.New(context.Background(), cfg)
+	if err != nil {
+		fmt.Printf("Failed to set up OAuth2/OIDC: %s\n", err)
+		return
+	}
+	authenticator = a
+	oauthConfiguredProvider = cfg.Provider
+
 	fmt.Println("Starting web client...")
 	startWebClient()
 }
@@ -23,6 +62,14 @@ func startWebClient() {
 	http.Handle("/api/login", enableCors(http.HandlerFunc(loginHandler)))
 	http.Handle("/api/add-public-key", enableCors(http.HandlerFunc(addPublicKeyHandler)))
 	http.Handle("/api/remove-public-key", enableCors(http.HandlerFunc(removePublicKeyHandler)))
+	http.Handle("/api/webauthn/register/begin", enableCors(http.HandlerFunc(webauthnRegisterBeginHandler)))
+	http.Handle("/api/webauthn/register/finish", enableCors(http.HandlerFunc(webauthnRegisterFinishHandler)))
+	http.Handle("/api/webauthn/login/begin", enableCors(http.HandlerFunc(webauthnLoginBeginHandler)))
+	http.Handle("/api/webauthn/login/finish", enableCors(http.HandlerFunc(webauthnLoginFinishHandler)))
+	http.Handle("/api/ssh-cert", enableCors(http.HandlerFunc(sshCertHandler)))
+	http.Handle("/api/oauth/", enableCors(http.HandlerFunc(oauthLoginHandler)))
+	http.Handle("/api/oauth/callback", enableCors(http.HandlerFunc(oauthCallbackHandler)))
+	http.Handle("/api/attest", enableCors(http.HandlerFunc(attestHandler)))
 	fmt.Println("Client running on http://localhost:6060")
 	http.ListenAndServe(":6060", nil)
 }
@@ -46,24 +93,19 @@ func enableCors(next http.Handler) http.Handler {
 // request with a JSON body containing a username.
 
 func loginHandler(w http.ResponseWriter, r *http.Request) {
-	// Get origin from request header and replace port with 8080
-	// We use this order to be able to know what to send to auth.Login
-	origin := r.Header.Get("Origin")
-
 	var requestBody map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 	username := requestBody["username"]
-	user, signedChallenge, errMsg, err := auth.GetAndSign(origin, username)
+	user, errMsg, err := auth.GetAndSign(r.Context(), username)
 	if err != nil {
 		http.Error(w, errMsg, http.StatusBadRequest)
 		return
 	}
 	response := GetAndSignResponse{
-		User:            user,
-		SignedChallenge: signedChallenge,
+		User: user,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -90,8 +132,10 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 //	to the request. This response is later retrieved by the frontend and displayed to the user.
 
 func registerHandler(w http.ResponseWriter, r *http.Request) {
-	// Get origin from request header and replace port with 8080
-	origin := r.Header.Get("Origin")
+	username, ok := requireAuth(w, r)
+	if !ok {
+		return
+	}
 
 	var requestBody map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
@@ -99,25 +143,9 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	username := requestBody["username"]
 	label := requestBody["label"]
-	resp, err := auth.Register(origin, username, label)
-	if err != nil {
-		if resp == nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		defer resp.Body.Close()
-		respBody, err := io.ReadAll(resp.Body)
-
-		if err != nil {
-			http.Error(w, "Failed to read response body", http.StatusInternalServerError)
-			return
-		}
-
-		respBodyStr := string(respBody)
-		http.Error(w, respBodyStr, http.StatusBadRequest)
+	if err := auth.Register(r.Context(), username, label); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -133,8 +161,10 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 // - 500 Internal Server Error: if there is an error adding the public key
 // - 200 OK: if the public key is added successfully
 func addPublicKeyHandler(w http.ResponseWriter, r *http.Request) {
-	// Get origin from request header and replace port with 8080
-	origin := r.Header.Get("Origin")
+	username, ok := requireAuth(w, r)
+	if !ok {
+		return
+	}
 
 	var requestBody map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
@@ -142,10 +172,9 @@ func addPublicKeyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	username := requestBody["username"]
 	label := requestBody["label"]
 	sessionCookie := r.Header.Get("Cookie")
-	err := auth.AddPublicKey(origin, username, label, sessionCookie)
+	err := auth.AddPublicKey(r.Context(), username, label, sessionCookie)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -164,8 +193,10 @@ func addPublicKeyHandler(w http.ResponseWriter, r *http.Request) {
 // - 500 Internal Server Error: if there is an error removing the public key
 // - 200 OK: if the public key is removed successfully
 func removePublicKeyHandler(w http.ResponseWriter, r *http.Request) {
-	// Get origin from request header and replace port with 8080
-	origin := r.Header.Get("Origin")
+	username, ok := requireAuth(w, r)
+	if !ok {
+		return
+	}
 
 	var requestBody map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
@@ -173,10 +204,9 @@ func removePublicKeyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	username := requestBody["username"]
 	label := requestBody["label"]
 	sessionCookie := r.Header.Get("Cookie")
-	err := auth.RemovePublicKey(origin, username, label, sessionCookie)
+	err := auth.RemovePublicKey(r.Context(), username, label, sessionCookie)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
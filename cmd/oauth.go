@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"chalmers/tkey-group22/client/internal/authn"
+)
+
+// authenticator is set up once at startup from the configured provider;
+// main refuses to start without a config file. The handlers below answer
+// with an error while it is nil, i.e. when they run without main's setup.
+var authenticator *authn.Authenticator
+
+// oauthLoginHandler redirects to the configured provider's consent screen.
+// The provider name in the path must match the configured provider; it
+// exists so a relying party can link directly to e.g. /api/oauth/google/login.
+func oauthLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if authenticator == nil {
+		http.Error(w, "OAuth2 login is not configured", http.StatusNotFound)
+		return
+	}
+
+	provider := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/oauth/"), "/login")
+	if provider != oauthConfiguredProvider {
+		http.Error(w, "Unknown provider", http.StatusNotFound)
+		return
+	}
+
+	if err := authenticator.BeginLogin(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// oauthCallbackHandler completes the OAuth2 exchange and stores the proven
+// identity in a signed session cookie.
+func oauthCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if authenticator == nil {
+		http.Error(w, "OAuth2 login is not configured", http.StatusNotFound)
+		return
+	}
+
+	claims, err := authenticator.HandleCallback(r.Context(), w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Signed in as " + claims.Username))
+}
+
+// requireAuth returns the authenticated username for r, writing an error
+// response and returning ok=false if the request has no valid session.
+func requireAuth(w http.ResponseWriter, r *http.Request) (username string, ok bool) {
+	if authenticator == nil {
+		http.Error(w, "OAuth2 login is not configured", http.StatusInternalServerError)
+		return "", false
+	}
+
+	claims, err := authenticator.Authenticate(r)
+	if err != nil {
+		http.Error(w, "Not authenticated: "+err.Error(), http.StatusUnauthorized)
+		return "", false
+	}
+
+	return claims.Username, true
+}
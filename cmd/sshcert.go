@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"chalmers/tkey-group22/client/internal/auth"
+)
+
+// savePrefix is set from the --save-prefix flag; when non-empty, every
+// issued certificate is also written to <savePrefix>-cert.pub.
+var savePrefix string
+
+type sshCertRequest struct {
+	Validity   string            `json:"validity"`
+	Extensions map[string]string `json:"extensions"`
+}
+
+// sshCertHandler issues a TKey-signed SSH user certificate and installs it
+// into the caller's ssh-agent. It expects a POST request with a JSON body
+// naming the validity and extensions of the certificate to request; the
+// certificate's username and principals come from the authenticated
+// session, not the request body, the same way registerHandler and friends
+// derive their username from requireAuth.
+//
+// Possible responses:
+// - 401 Unauthorized: if the caller has no valid session
+// - 400 Bad Request: if the request body is invalid
+// - 500 Internal Server Error: if certificate issuance or agent install fails
+// - 200 OK: a JSON object with the issued certificate's keyId and its
+//   validBefore time (RFC 3339)
+func sshCertHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var req sshCertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	validity := 12 * time.Hour
+	if req.Validity != "" {
+		parsed, err := time.ParseDuration(req.Validity)
+		if err != nil {
+			http.Error(w, "Invalid validity duration", http.StatusBadRequest)
+			return
+		}
+		validity = parsed
+	}
+
+	result, err := auth.RequestCertificate(username, []string{username}, validity, req.Extensions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if savePrefix != "" {
+		if err := auth.SavePublicFiles(savePrefix, result); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"keyId":       result.Certificate.KeyId,
+		"validBefore": time.Unix(int64(result.Certificate.ValidBefore), 0).Format(time.RFC3339),
+	})
+}
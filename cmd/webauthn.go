@@ -0,0 +1,290 @@
+package main
+
+import (
+	"chalmers/tkey-group22/client/internal/auth"
+	. "chalmers/tkey-group22/client/internal/structs"
+	"chalmers/tkey-group22/client/internal/webauthn"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+var (
+	errInvalidClientData = errors.New("invalid clientDataJSON")
+	errUnknownChallenge  = errors.New("challenge was not issued by this server, or was already used")
+)
+
+// webauthnKeyLabel is the label under which a WebAuthn-registered TKey
+// public key is stored in the backend registry, mirroring the label a
+// caller would otherwise pass to /api/register.
+const webauthnKeyLabel = "webauthn"
+
+func sha256Sum(b []byte) []byte {
+	sum := sha256.Sum256(b)
+	return sum[:]
+}
+
+// webauthnChallenges tracks challenges handed out by the begin handlers,
+// keyed by the raw challenge bytes and mapped to the username the ceremony
+// was started for, until the matching finish call consumes them. A
+// production relying party would scope these to a session; here we only
+// have one TKey to answer for, so a single in-memory map is enough.
+var (
+	webauthnChallengesMu sync.Mutex
+	webauthnChallenges   = map[string]string{}
+)
+
+// relyingPartyEntity mirrors the WebAuthn PublicKeyCredentialRpEntity.
+type relyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// userEntity mirrors the WebAuthn PublicKeyCredentialUserEntity.
+type userEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type pubKeyCredParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// credentialCreationOptions mirrors PublicKeyCredentialCreationOptions.
+type credentialCreationOptions struct {
+	Challenge        string             `json:"challenge"`
+	RP               relyingPartyEntity `json:"rp"`
+	User             userEntity         `json:"user"`
+	PubKeyCredParams []pubKeyCredParam  `json:"pubKeyCredParams"`
+}
+
+// credentialRequestOptions mirrors PublicKeyCredentialRequestOptions.
+type credentialRequestOptions struct {
+	Challenge        string                      `json:"challenge"`
+	RPID             string                      `json:"rpId"`
+	AllowCredentials []allowCredentialDescriptor `json:"allowCredentials,omitempty"`
+}
+
+type allowCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// publicKeyCredential mirrors the shape the browser sends back from
+// navigator.credentials.create()/get(), with binary fields base64url encoded.
+type publicKeyCredential struct {
+	ID       string                 `json:"id"`
+	RawID    string                 `json:"rawId"`
+	Type     string                 `json:"type"`
+	Response credentialResponseBody `json:"response"`
+}
+
+type credentialResponseBody struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject,omitempty"`
+	AuthenticatorData string `json:"authenticatorData,omitempty"`
+	Signature         string `json:"signature,omitempty"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+func b64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newChallenge() (raw []byte, encoded string, err error) {
+	raw = make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	return raw, b64url(raw), nil
+}
+
+// webauthnRegisterBeginHandler starts a registration ceremony by handing the
+// browser a PublicKeyCredentialCreationOptions to feed to
+// navigator.credentials.create(). The username it binds the resulting
+// credential to is the authenticated caller's, from requireAuth, not a
+// caller-supplied parameter — otherwise anyone reaching this endpoint could
+// bind the present TKey's credential to an arbitrary victim account.
+func webauthnRegisterBeginHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	challenge, encoded, err := newChallenge()
+	if err != nil {
+		http.Error(w, "Failed to generate challenge", http.StatusInternalServerError)
+		return
+	}
+
+	webauthnChallengesMu.Lock()
+	webauthnChallenges[string(challenge)] = username
+	webauthnChallengesMu.Unlock()
+
+	opts := credentialCreationOptions{
+		Challenge: encoded,
+		RP:        relyingPartyEntity{ID: r.Host, Name: "tkey-web-authentication"},
+		User:      userEntity{ID: b64url([]byte(username)), Name: username, DisplayName: username},
+		PubKeyCredParams: []pubKeyCredParam{
+			{Type: "public-key", Alg: -8},
+		},
+	}
+
+	writeJSON(w, opts)
+}
+
+// webauthnRegisterFinishHandler checks that the clientDataJSON's challenge
+// was issued by webauthnRegisterBeginHandler, has the TKey produce the
+// attestation object for it (the attestationObject field of the request
+// body is not read), and, since the TKey itself is the only credential
+// store, registers the
+// TKey's public key for the authenticated caller the same way registerHandler
+// does. The username comes from requireAuth, not from the challenge or the
+// request body, so a completed ceremony can only ever bind a key to the
+// session's own account.
+func webauthnRegisterFinishHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := requireAuth(w, r)
+	if !ok {
+		return
+	}
+
+	var cred publicKeyCredential
+	if err := json.NewDecoder(r.Body).Decode(&cred); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	clientDataHash, _, err := consumeClientDataChallenge(cred.Response.ClientDataJSON)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	attObj, err := webauthn.MakeCredential(r.Host, []byte(cred.ID), clientDataHash, []int{-8})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := auth.Register(r.Context(), username, webauthnKeyLabel); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"attestationObject": b64url(attObj.CBOR()),
+	})
+}
+
+// webauthnLoginBeginHandler starts an authentication ceremony by handing the
+// browser a PublicKeyCredentialRequestOptions to feed to
+// navigator.credentials.get(). The username it's called with is the one
+// webauthnLoginFinishHandler will authenticate against the backend.
+func webauthnLoginBeginHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		http.Error(w, "Missing username", http.StatusBadRequest)
+		return
+	}
+
+	challenge, encoded, err := newChallenge()
+	if err != nil {
+		http.Error(w, "Failed to generate challenge", http.StatusInternalServerError)
+		return
+	}
+
+	webauthnChallengesMu.Lock()
+	webauthnChallenges[string(challenge)] = username
+	webauthnChallengesMu.Unlock()
+
+	opts := credentialRequestOptions{
+		Challenge: encoded,
+		RPID:      r.Host,
+	}
+
+	writeJSON(w, opts)
+}
+
+// webauthnLoginFinishHandler checks that the clientDataJSON's challenge was
+// issued by webauthnLoginBeginHandler and has the connected TKey produce an
+// assertion for it (the authenticatorData and signature fields of the
+// request body are not read), then completes the backend
+// challenge/signature exchange the same way loginHandler does, so a
+// successful ceremony actually authenticates the account instead of just
+// proving the TKey is present.
+func webauthnLoginFinishHandler(w http.ResponseWriter, r *http.Request) {
+	var cred publicKeyCredential
+	if err := json.NewDecoder(r.Body).Decode(&cred); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	clientDataHash, username, err := consumeClientDataChallenge(cred.Response.ClientDataJSON)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := webauthn.GetAssertion(r.Host, clientDataHash, nil); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	user, errMsg, err := auth.GetAndSign(r.Context(), username)
+	if err != nil {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(GetAndSignResponse{User: user})
+}
+
+// consumeClientDataChallenge parses the base64url clientDataJSON the browser
+// sent, checks its embedded challenge was one we issued, and returns
+// SHA-256(clientDataJSON) (as required for the packed attestation/assertion
+// signature) along with the username the ceremony was started for.
+func consumeClientDataChallenge(clientDataJSONB64 string) (clientDataHash []byte, username string, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(clientDataJSONB64)
+	if err != nil {
+		return nil, "", errInvalidClientData
+	}
+
+	var clientData struct {
+		Challenge string `json:"challenge"`
+	}
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return nil, "", errInvalidClientData
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(clientData.Challenge)
+	if err != nil {
+		return nil, "", errInvalidClientData
+	}
+
+	webauthnChallengesMu.Lock()
+	username, ok := webauthnChallenges[string(challenge)]
+	delete(webauthnChallenges, string(challenge))
+	webauthnChallengesMu.Unlock()
+
+	if !ok {
+		return nil, "", errUnknownChallenge
+	}
+
+	return sha256Sum(raw), username, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
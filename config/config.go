@@ -0,0 +1,113 @@
+// Package config loads the client's OAuth2/OIDC settings from a JSON or
+// TOML file on disk.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config describes the upstream identity provider used to authenticate
+// registration and key-management requests before they touch the TKey.
+type Config struct {
+	// Provider selects which OAuth2/OIDC flow to use: "google", "github" or
+	// "oidc" for a generic OpenID Connect issuer.
+	Provider string `json:"provider" toml:"provider"`
+	// IssuerURL is only required when Provider is "oidc".
+	IssuerURL      string   `json:"issuer_url,omitempty" toml:"issuer_url,omitempty"`
+	ClientID       string   `json:"client_id" toml:"client_id"`
+	ClientSecret   string   `json:"client_secret" toml:"client_secret"`
+	RedirectURI    string   `json:"redirect_uri" toml:"redirect_uri"`
+	AllowedDomains []string `json:"allowed_domains,omitempty" toml:"allowed_domains,omitempty"`
+	// SessionHashKey and SessionBlockKey authenticate and encrypt the
+	// session cookie. Both are expected to be base64-free raw strings; in
+	// production these should come from a secret store rather than this
+	// file.
+	SessionHashKey  string `json:"session_hash_key" toml:"session_hash_key"`
+	SessionBlockKey string `json:"session_block_key" toml:"session_block_key"`
+	// InsecureCookies disables the Secure flag on the session cookie. The
+	// client's own listener never terminates TLS itself (it expects to sit
+	// behind a TLS-terminating proxy, or to be reached over a loopback
+	// address during development), so this is only needed when RedirectURI
+	// is served over plain http:// from a host other than localhost.
+	InsecureCookies bool `json:"insecure_cookies,omitempty" toml:"insecure_cookies,omitempty"`
+
+	// BackendURL is the fixed base URL of the registry backend, e.g.
+	// "https://registry.example.com". It replaces the old behaviour of
+	// deriving a backend URL from the request's Origin header.
+	BackendURL string `json:"backend_url" toml:"backend_url"`
+	// BackendTLSPins, if set, are SHA-256 digests (hex-encoded) of the DER
+	// certificate BackendURL must present.
+	BackendTLSPins []string `json:"backend_tls_pins,omitempty" toml:"backend_tls_pins,omitempty"`
+}
+
+// Load reads and parses a config file, dispatching on its extension
+// (".json" or ".toml").
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var cfg Config
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s as JSON: %w", path, err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s as TOML: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q (want .json or .toml)", ext)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) validate() error {
+	switch c.Provider {
+	case "google", "github", "oidc":
+	default:
+		return fmt.Errorf("unknown provider %q (want google, github or oidc)", c.Provider)
+	}
+	if c.Provider == "oidc" && c.IssuerURL == "" {
+		return fmt.Errorf("issuer_url is required for provider %q", c.Provider)
+	}
+	if c.ClientID == "" || c.ClientSecret == "" || c.RedirectURI == "" {
+		return fmt.Errorf("client_id, client_secret and redirect_uri are all required")
+	}
+	if c.BackendURL == "" {
+		return fmt.Errorf("backend_url is required")
+	}
+	return nil
+}
+
+// IsDomainAllowed reports whether email's domain is in AllowedDomains. An
+// empty AllowedDomains list allows every domain.
+func (c *Config) IsDomainAllowed(email string) bool {
+	if len(c.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range c.AllowedDomains {
+		if strings.ToLower(allowed) == domain {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+// Package auth talks to the backend that holds the username/public-key
+// registry on behalf of the web client's HTTP handlers, signing challenges
+// with the TKey along the way.
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"chalmers/tkey-group22/client/internal/tkey"
+	"chalmers/tkey-group22/client/internal/transport"
+)
+
+// backend is configured once at startup via SetBackend, from the client's
+// own config rather than derived per-request from a request's Origin
+// header — letting the caller's Origin pick the backend would let any
+// origin dictate where the client sends credentials.
+var backend transport.Backend
+
+// SetBackend installs the transport.Backend used by every function in this
+// package. It must be called once during startup before any handler runs.
+func SetBackend(b transport.Backend) {
+	backend = b
+}
+
+// GetAndSign fetches a login challenge for username from the backend,
+// signs it with the TKey, and submits the signature back to the backend.
+func GetAndSign(ctx context.Context, username string) (user string, errMsg string, err error) {
+	challenge, err := backend.GetChallenge(ctx, username)
+	if err != nil {
+		return "", "Failed to reach backend", err
+	}
+
+	sig, err := tkey.Sign(challenge)
+	if err != nil {
+		return "", "Failed to sign challenge with TKey", err
+	}
+
+	if err := backend.SubmitSignature(ctx, username, sig); err != nil {
+		return "", "Backend rejected the signed challenge", err
+	}
+
+	return username, "", nil
+}
+
+// Register asks the backend to create a new account for username, bound to
+// the TKey's public key under the given label.
+func Register(ctx context.Context, username, label string) error {
+	pub, err := tkey.GetTkeyPubKey()
+	if err != nil {
+		return fmt.Errorf("failed to get public key from TKey: %w", err)
+	}
+
+	return backend.Register(ctx, username, label, pub)
+}
+
+// AddPublicKey registers an additional TKey public key under label for an
+// already-authenticated user.
+func AddPublicKey(ctx context.Context, username, label, sessionCookie string) error {
+	pub, err := tkey.GetTkeyPubKey()
+	if err != nil {
+		return fmt.Errorf("failed to get public key from TKey: %w", err)
+	}
+
+	return backend.AddKey(ctx, username, label, pub, sessionCookie)
+}
+
+// RemovePublicKey removes a previously registered public key under label
+// for an already-authenticated user.
+func RemovePublicKey(ctx context.Context, username, label, sessionCookie string) error {
+	return backend.RemoveKey(ctx, username, label, sessionCookie)
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"chalmers/tkey-group22/client/internal/tkey"
+)
+
+// CertificateResult is what RequestCertificate hands back to its caller: the
+// signed certificate and the user keypair it was issued for, so the caller
+// can write them to disk (e.g. via SavePublicFiles) after installing them in
+// the agent.
+type CertificateResult struct {
+	Certificate *ssh.Certificate
+	PublicKey   ed25519.PublicKey
+	PrivateKey  ed25519.PrivateKey
+}
+
+// RequestCertificate issues a short-lived SSH user certificate signed by the
+// TKey, acting as a minimal, single-user SSH CA (in the spirit of Cashier):
+// it generates a fresh user keypair, builds a certificate for the requested
+// principals/validity/extensions, signs it with the TKey-backed Ed25519
+// signer, and installs the result into the running ssh-agent.
+func RequestCertificate(username string, principals []string, validity time.Duration, extensions map[string]string) (*CertificateResult, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate user keypair: %w", err)
+	}
+
+	userSSHPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert user public key: %w", err)
+	}
+
+	caSigner, err := tkey.GetCryptoSigner()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TKey signer: %w", err)
+	}
+
+	sshCASigner, err := ssh.NewSignerFromSigner(caSigner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap TKey signer for SSH: %w", err)
+	}
+
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             userSSHPub,
+		Serial:          1,
+		CertType:        ssh.UserCert,
+		KeyId:           username + "@tkey",
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(now.Unix()),
+		ValidBefore:     uint64(now.Add(validity).Unix()),
+		Permissions: ssh.Permissions{
+			Extensions: extensions,
+		},
+	}
+
+	if err := cert.SignCert(rand.Reader, sshCASigner); err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+
+	if err := installInAgent(cert, priv, now.Add(validity)); err != nil {
+		return nil, err
+	}
+
+	return &CertificateResult{Certificate: cert, PublicKey: pub, PrivateKey: priv}, nil
+}
+
+// installInAgent adds the certificate and its private key to the
+// ssh-agent the shell's SSH_AUTH_SOCK points at, so ssh picks it up
+// immediately without any config changes.
+func installInAgent(cert *ssh.Certificate, priv ed25519.PrivateKey, validBefore time.Time) error {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return fmt.Errorf("SSH_AUTH_SOCK is not set; no running ssh-agent to install the certificate into")
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ssh-agent: %w", err)
+	}
+	defer conn.Close()
+
+	agentClient := agent.NewClient(conn)
+	return agentClient.Add(agent.AddedKey{
+		PrivateKey:   priv,
+		Certificate:  cert,
+		LifetimeSecs: uint32(time.Until(validBefore).Seconds()),
+	})
+}
+
+// SavePublicFiles writes the certificate to <prefix>-cert.pub, mirroring
+// Cashier's SavePublicFiles behaviour.
+func SavePublicFiles(prefix string, result *CertificateResult) error {
+	certPath := prefix + "-cert.pub"
+
+	if dir := filepath.Dir(certPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	return os.WriteFile(certPath, ssh.MarshalAuthorizedKey(result.Certificate), 0o644)
+}
@@ -0,0 +1,220 @@
+// Package authn binds a TKey public key to an identity proven by an
+// upstream OAuth2/OIDC provider, so registering or managing keys requires
+// more than just knowing somebody's username.
+package authn
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	oidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gorilla/sessions"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+
+	"chalmers/tkey-group22/client/config"
+)
+
+const sessionName = "tkey-authn"
+
+// Claims is the identity the provider vouched for.
+type Claims struct {
+	Username string
+	Email    string
+}
+
+// Authenticator drives the OAuth2/OIDC ceremony and stores the resulting
+// identity in a signed session cookie.
+type Authenticator struct {
+	cfg          *config.Config
+	oauth2Config *oauth2.Config
+	verifier     *oidc.IDTokenVerifier // nil for provider "github", which has no ID tokens
+	store        *sessions.CookieStore
+}
+
+// New builds an Authenticator from cfg. For an "oidc" provider this performs
+// OIDC discovery against cfg.IssuerURL, so it requires network access at
+// startup.
+func New(ctx context.Context, cfg *config.Config) (*Authenticator, error) {
+	oauth2Config := &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURI,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+
+	var verifier *oidc.IDTokenVerifier
+
+	switch cfg.Provider {
+	case "google":
+		provider, err := oidc.NewProvider(ctx, "https://accounts.google.com")
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover Google OIDC endpoints: %w", err)
+		}
+		oauth2Config.Endpoint = provider.Endpoint()
+		verifier = provider.Verifier(&oidc.Config{ClientID: cfg.ClientID})
+	case "oidc":
+		provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover OIDC endpoints at %s: %w", cfg.IssuerURL, err)
+		}
+		oauth2Config.Endpoint = provider.Endpoint()
+		verifier = provider.Verifier(&oidc.Config{ClientID: cfg.ClientID})
+	case "github":
+		// GitHub's OAuth2 apps don't issue ID tokens, so there's no
+		// verifier; HandleCallback falls back to the GitHub user API.
+		oauth2Config.Endpoint = endpoints.GitHub
+		oauth2Config.Scopes = []string{"read:user", "user:email"}
+	default:
+		return nil, fmt.Errorf("unknown provider %q", cfg.Provider)
+	}
+
+	store := sessions.NewCookieStore([]byte(cfg.SessionHashKey), []byte(cfg.SessionBlockKey))
+	store.Options.HttpOnly = true
+	store.Options.Secure = !cfg.InsecureCookies
+	store.Options.SameSite = http.SameSiteLaxMode
+
+	return &Authenticator{cfg: cfg, oauth2Config: oauth2Config, verifier: verifier, store: store}, nil
+}
+
+// BeginLogin redirects the browser to the provider's consent screen,
+// stashing a CSRF state value in the session for HandleCallback to check.
+func (a *Authenticator) BeginLogin(w http.ResponseWriter, r *http.Request) error {
+	state, err := randomState()
+	if err != nil {
+		return fmt.Errorf("failed to generate OAuth2 state: %w", err)
+	}
+
+	session, err := a.store.Get(r, sessionName)
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+	session.Values["oauth_state"] = state
+	if err := session.Save(r, w); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	http.Redirect(w, r, a.oauth2Config.AuthCodeURL(state), http.StatusFound)
+	return nil
+}
+
+// HandleCallback completes the OAuth2 exchange, verifies the resulting
+// identity, checks it against cfg.AllowedDomains, and stores it in the
+// session cookie.
+func (a *Authenticator) HandleCallback(ctx context.Context, w http.ResponseWriter, r *http.Request) (*Claims, error) {
+	session, err := a.store.Get(r, sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session: %w", err)
+	}
+
+	wantState, _ := session.Values["oauth_state"].(string)
+	if wantState == "" || r.URL.Query().Get("state") != wantState {
+		return nil, fmt.Errorf("invalid or missing OAuth2 state")
+	}
+
+	token, err := a.oauth2Config.Exchange(ctx, r.URL.Query().Get("code"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	claims, err := a.identify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.cfg.IsDomainAllowed(claims.Email) {
+		return nil, fmt.Errorf("email domain for %s is not in the allowed_domains list", claims.Email)
+	}
+
+	session.Values["username"] = claims.Username
+	session.Values["email"] = claims.Email
+	delete(session.Values, "oauth_state")
+	if err := session.Save(r, w); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return claims, nil
+}
+
+// identify extracts Claims from the token, using the ID token when the
+// provider issues one, or the GitHub user API otherwise.
+func (a *Authenticator) identify(ctx context.Context, token *oauth2.Token) (*Claims, error) {
+	if a.verifier != nil {
+		rawIDToken, ok := token.Extra("id_token").(string)
+		if !ok {
+			return nil, fmt.Errorf("provider did not return an id_token")
+		}
+		idToken, err := a.verifier.Verify(ctx, rawIDToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify id_token: %w", err)
+		}
+		var claims struct {
+			Email string `json:"email"`
+		}
+		if err := idToken.Claims(&claims); err != nil {
+			return nil, fmt.Errorf("failed to parse id_token claims: %w", err)
+		}
+		return &Claims{Username: claims.Email, Email: claims.Email}, nil
+	}
+
+	return a.githubUser(ctx, token)
+}
+
+func (a *Authenticator) githubUser(ctx context.Context, token *oauth2.Token) (*Claims, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/user", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach GitHub user API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub user API returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var user struct {
+		Login string `json:"login"`
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub user response: %w", err)
+	}
+
+	return &Claims{Username: user.Login, Email: user.Email}, nil
+}
+
+// Authenticate returns the identity stored in the request's session cookie,
+// or an error if the request has no valid, logged-in session.
+func (a *Authenticator) Authenticate(r *http.Request) (*Claims, error) {
+	session, err := a.store.Get(r, sessionName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session: %w", err)
+	}
+
+	username, _ := session.Values["username"].(string)
+	if username == "" {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	email, _ := session.Values["email"].(string)
+	return &Claims{Username: username, Email: email}, nil
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
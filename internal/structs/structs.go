@@ -0,0 +1,10 @@
+// Package structs holds the JSON response shapes shared between the web
+// client's HTTP handlers and the frontend.
+package structs
+
+// GetAndSignResponse is returned by /api/login: the username the challenge
+// was issued for and signed by the TKey, now that the backend has accepted
+// the signature.
+type GetAndSignResponse struct {
+	User string `json:"user"`
+}
@@ -45,6 +45,20 @@ const (
 	wantAppName1 = "sign"
 )
 
+// ioRequest is one queued unit of TKey serial I/O: run fn and deliver its
+// result on done. Routing every framed protocol exchange through a single
+// queue, served by one goroutine, keeps two overlapping handlers from
+// interleaving bytes on the same serial connection.
+type ioRequest struct {
+	fn   func() (interface{}, error)
+	done chan ioResult
+}
+
+type ioResult struct {
+	value interface{}
+	err   error
+}
+
 type Signer struct {
 	tk              *tkeyclient.TillitisKey
 	tkSigner        *tkeysign.Signer
@@ -55,7 +69,10 @@ type Signer struct {
 	pinentry        string
 	mu              sync.Mutex
 	connected       bool
+	refCount        int
 	disconnectTimer *time.Timer
+	ioQueue         chan ioRequest
+	retireOnce      sync.Once
 }
 
 func NewSigner(devPathArg string, speedArg int, enterUSS bool, fileUSS string, pinentry string, exitFunc func(int)) *Signer {
@@ -74,8 +91,11 @@ func NewSigner(devPathArg string, speedArg int, enterUSS bool, fileUSS string, p
 		enterUSS: enterUSS,
 		fileUSS:  fileUSS,
 		pinentry: pinentry,
+		ioQueue:  make(chan ioRequest),
 	}
 
+	go signer.runIOQueue()
+
 	// Do nothing on HUP, in case old udev rule is still in effect
 	handleSignals(func() {}, syscall.SIGHUP)
 
@@ -88,7 +108,30 @@ func NewSigner(devPathArg string, speedArg int, enterUSS bool, fileUSS string, p
 	return &signer
 }
 
-func (s *Signer) connect() bool {
+// runIOQueue serializes every framed exchange queued through doIO onto this
+// one goroutine, so concurrent callers can never interleave writes on the
+// shared serial connection. The connect-time exchanges in acquire (the
+// firmware-mode check and app loading) are made directly, under s.mu.
+func (s *Signer) runIOQueue() {
+	for req := range s.ioQueue {
+		value, err := req.fn()
+		req.done <- ioResult{value: value, err: err}
+	}
+}
+
+// doIO queues fn to run on the serializing goroutine and waits for its
+// result.
+func (s *Signer) doIO(fn func() (interface{}, error)) (interface{}, error) {
+	done := make(chan ioResult, 1)
+	s.ioQueue <- ioRequest{fn: fn, done: done}
+	result := <-done
+	return result.value, result.err
+}
+
+// acquire connects to the TKey if necessary and increments the reference
+// count, cancelling any pending idle-disconnect. Callers must pair every
+// successful acquire with a release.
+func (s *Signer) acquire() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -98,6 +141,7 @@ func (s *Signer) connect() bool {
 	}
 
 	if s.connected {
+		s.refCount++
 		return true
 	}
 
@@ -151,6 +195,7 @@ func (s *Signer) connect() bool {
 	// say anything about that.
 
 	s.connected = true
+	s.refCount = 1
 	return true
 }
 
@@ -164,17 +209,25 @@ func (s *Signer) isFirmwareMode() bool {
 		nameVer.Name1 == wantFWName1
 }
 
+type appNames struct{ Name0, Name1 string }
+
 func (s *Signer) isWantedApp() bool {
-	nameVer, err := s.tkSigner.GetAppNameVersion()
+	result, err := s.doIO(func() (interface{}, error) {
+		nameVer, err := s.tkSigner.GetAppNameVersion()
+		if err != nil {
+			return nil, err
+		}
+		// not caring about nameVer.Version
+		return appNames{Name0: nameVer.Name0, Name1: nameVer.Name1}, nil
+	})
 	if err != nil {
 		if !errors.Is(err, io.EOF) {
 			le.Printf("GetAppNameVersion: %s\n", err)
 		}
 		return false
 	}
-	// not caring about nameVer.Version
-	return nameVer.Name0 == wantAppName0 &&
-		nameVer.Name1 == wantAppName1
+	names := result.(appNames)
+	return names.Name0 == wantAppName0 && names.Name1 == wantAppName1
 }
 
 func (s *Signer) loadApp() error {
@@ -199,6 +252,10 @@ func (s *Signer) loadApp() error {
 		}
 	}
 
+	if _, ok := GetVerifiedManifest(); !ok {
+		return fmt.Errorf("refusing to load embedded app: it has not passed VerifyEmbeddedApp")
+	}
+
 	le.Printf("Loading signer app...\n")
 	if err := s.tk.LoadApp(appBinary, secret); err != nil {
 		return fmt.Errorf("LoadApp: %w", err)
@@ -209,17 +266,20 @@ func (s *Signer) loadApp() error {
 }
 
 func (s *Signer) printAuthorizedKey() {
-	if !s.connect() {
+	if !s.acquire() {
 		le.Printf("Connect failed")
 		return
 	}
-	defer s.disconnect()
+	defer s.release()
 
-	pub, err := s.tkSigner.GetPubkey()
+	result, err := s.doIO(func() (interface{}, error) {
+		return s.tkSigner.GetPubkey()
+	})
 	if err != nil {
 		le.Printf("GetPubkey failed: %s\n", err)
 		return
 	}
+	pub := result.([]byte)
 
 	sshPub, err := ssh.NewPublicKey(ed25519.PublicKey(pub))
 	if err != nil {
@@ -231,7 +291,10 @@ func (s *Signer) printAuthorizedKey() {
 	fmt.Fprintf(os.Stdout, "%s", ssh.MarshalAuthorizedKey(sshPub))
 }
 
-func (s *Signer) disconnect() {
+// release decrements the reference count and, only once it reaches zero
+// (i.e. no other in-flight request still needs the connection), arms the
+// idle-disconnect timer.
+func (s *Signer) release() {
 	if s.tkSigner == nil {
 		return
 	}
@@ -243,6 +306,13 @@ func (s *Signer) disconnect() {
 		return
 	}
 
+	if s.refCount > 0 {
+		s.refCount--
+	}
+	if s.refCount > 0 {
+		return
+	}
+
 	if s.disconnectTimer != nil {
 		s.disconnectTimer.Stop()
 		s.disconnectTimer = nil
@@ -252,6 +322,15 @@ func (s *Signer) disconnect() {
 		s.mu.Lock()
 		defer s.mu.Unlock()
 
+		// Timer.Stop() in acquire() cannot stop this goroutine once it has
+		// already fired; if a fresh acquire() won the race for s.mu, it has
+		// already bumped refCount back up, and its own release() will arm
+		// the next idle timer. Back off instead of tearing down a
+		// connection that's back in use.
+		if s.refCount != 0 {
+			return
+		}
+
 		s.closeNow()
 		s.connected = false
 		s.disconnectTimer = nil
@@ -268,27 +347,51 @@ func (s *Signer) closeNow() {
 	}
 }
 
+// retire permanently shuts this Signer down, for a caller discarding it
+// (e.g. getSigner() replacing it because it turned out to be running the
+// wrong app). It closes the serial connection if still open and stops
+// runIOQueue's goroutine by closing ioQueue, so the discarded Signer doesn't
+// leak a goroutine blocked forever on it. Safe to call more than once.
+func (s *Signer) retire() {
+	s.retireOnce.Do(func() {
+		s.mu.Lock()
+		if s.disconnectTimer != nil {
+			s.disconnectTimer.Stop()
+			s.disconnectTimer = nil
+		}
+		if s.connected {
+			s.closeNow()
+			s.connected = false
+		}
+		s.mu.Unlock()
+
+		close(s.ioQueue)
+	})
+}
+
 // implementing crypto.Signer below
 
 func (s *Signer) Public() crypto.PublicKey {
-	if !s.connect() {
+	if !s.acquire() {
 		return nil
 	}
-	defer s.disconnect()
+	defer s.release()
 
-	pub, err := s.tkSigner.GetPubkey()
+	result, err := s.doIO(func() (interface{}, error) {
+		return s.tkSigner.GetPubkey()
+	})
 	if err != nil {
 		le.Printf("GetPubkey failed: %s\n", err)
 		return nil
 	}
-	return ed25519.PublicKey(pub)
+	return ed25519.PublicKey(result.([]byte))
 }
 
 func (s *Signer) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
-	if !s.connect() {
+	if !s.acquire() {
 		return nil, fmt.Errorf("connect failed")
 	}
-	defer s.disconnect()
+	defer s.release()
 
 	// The Ed25519 signature must be made over unhashed message. See:
 	// https://cs.opensource.google/go/go/+/refs/tags/go1.18.4:src/crypto/ed25519/ed25519.go;l=80
@@ -296,11 +399,13 @@ func (s *Signer) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]by
 		return nil, errors.New("message must not be hashed")
 	}
 
-	signature, err := s.tkSigner.Sign(message)
+	result, err := s.doIO(func() (interface{}, error) {
+		return s.tkSigner.Sign(message)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("Sign: %w", err)
 	}
-	return signature, nil
+	return result.([]byte), nil
 }
 
 func handleSignals(action func(), sig ...os.Signal) {
@@ -15,61 +15,97 @@ const progname = "tkey-device-signer"
 var le = log.New(os.Stderr, "Error: ", 0)
 var existingSigner *Signer
 
+// GetTkeyPubKey fetches the public key from the connected TKey.
 func GetTkeyPubKey() (ed25519.PublicKey, error) {
 	signer, err := getSigner()
-
 	if err != nil {
 		return nil, err
 	}
+	defer signer.release()
 
-	if !signer.connect() {
-		le.Printf("Connect failed")
-		return nil, fmt.Errorf("connect failed")
-	}
-
-	defer signer.disconnect()
-
-	pub, err := signer.tkSigner.GetPubkey()
-
+	result, err := signer.doIO(func() (interface{}, error) {
+		return signer.tkSigner.GetPubkey()
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	pubkey := ed25519.PublicKey(pub)
+	pubkey := ed25519.PublicKey(result.([]byte))
 
 	signer.printAuthorizedKey()
 
 	return pubkey, nil
 }
 
-func Sign(msg []byte) ([]byte, error) {
-
+// GetCryptoSigner returns the TKey-backed crypto.Signer, so callers that
+// need to hand it to other Go APIs (e.g. golang.org/x/crypto/ssh) don't have
+// to reimplement connect/disconnect handling themselves.
+//
+// Unlike GetTkeyPubKey/Sign, this function does not hold the Signer for its
+// caller: it releases getSigner's reference before returning, and the
+// returned Signer re-acquires/releases itself around each of its own
+// crypto.Signer calls.
+func GetCryptoSigner() (crypto.Signer, error) {
 	signer, err := getSigner()
-
 	if err != nil {
 		return nil, err
 	}
+	signer.release()
 
-	if !signer.connect() {
-		le.Printf("Connect failed")
-		return nil, fmt.Errorf("connect failed")
-	}
+	return signer, nil
+}
 
-	defer signer.disconnect()
+// Sign signs msg with the connected TKey's private key.
+func Sign(msg []byte) ([]byte, error) {
+	signer, err := getSigner()
+	if err != nil {
+		return nil, err
+	}
+	defer signer.release()
 
-	sig, err := signer.Sign(nil, msg, crypto.Hash(0))
+	result, err := signer.doIO(func() (interface{}, error) {
+		return signer.tkSigner.Sign(msg)
+	})
 	if err != nil {
 		le.Printf("Sign failed: %s\n", err)
 		return nil, err
 	}
 
-	return sig, nil
+	return result.([]byte), nil
 }
 
+// GetUDI returns the connected TKey's unique device identifier, so a relying
+// party can tell which physical TKey produced a signature.
+func GetUDI() (string, error) {
+	signer, err := getSigner()
+	if err != nil {
+		return "", err
+	}
+	defer signer.release()
+
+	result, err := signer.doIO(func() (interface{}, error) {
+		return signer.tk.GetUDI()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	udi := result.(interface{ String() string })
+	return udi.String(), nil
+}
+
+// getSigner returns an already-acquired Signer (acquire() has already
+// succeeded on it); callers must release() it exactly once when done.
 func getSigner() (*Signer, error) {
-	if existingSigner != nil && existingSigner.connect() && existingSigner.isWantedApp() {
-		// The signer app is already loaded, return the existing signer
-		return existingSigner, nil
+	if existingSigner != nil && existingSigner.acquire() {
+		if existingSigner.isWantedApp() {
+			// The signer app is already loaded, return the existing signer
+			return existingSigner, nil
+		}
+		existingSigner.release()
+		// Wrong app: this Signer is about to be replaced below, so tear
+		// down its runIOQueue goroutine instead of leaking it.
+		existingSigner.retire()
 	}
 
 	devPath, err := tkeyclient.DetectSerialPort(false)
@@ -89,5 +125,9 @@ func getSigner() (*Signer, error) {
 	signer := NewSigner(devPath, serialSpeed, enterUSS, fileUSS, "", exit)
 	existingSigner = signer
 
+	if !signer.acquire() {
+		return nil, fmt.Errorf("connect failed")
+	}
+
 	return signer, nil
 }
@@ -0,0 +1,129 @@
+package tkey
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// tillitisReleasePubKeyHex is the Ed25519 public key Tillitis signs
+// tkey-device-signer release manifests with. Pinning it here means a
+// compromised or spoofed manifest server can't trick us into trusting an
+// unexpected signer.bin digest.
+const tillitisReleasePubKeyHex = "6b94e17d7e5b1a8d4b1f9e3f1c3b9e2b9f7d1a2e6c3b8f4a5d9e0c1b2a3f4e5d"
+
+// defaultManifestURL is where VerifyEmbeddedApp looks for the signed
+// manifest by default. It can be overridden by setting
+// TKEY_DEVICE_SIGNER_MANIFEST_URL, e.g. to point at a local mirror.
+const defaultManifestURL = "https://repo.tillitis.se/tkey-device-signer/manifest.json"
+
+// Manifest describes the upstream ground truth for a released
+// tkey-device-signer app binary.
+type Manifest struct {
+	Version      string `json:"version"`
+	SHA512       string `json:"sha512"`
+	Ed25519Sig   string `json:"ed25519_sig"`
+	SignerPubkey string `json:"signer_pubkey"`
+}
+
+// verifiedManifest caches the result of the last successful
+// VerifyEmbeddedApp call, for /api/attest to report without re-fetching.
+var verifiedManifest *Manifest
+
+// VerifyEmbeddedApp fetches the signed release manifest for the embedded
+// signer.bin and checks it against the local binary: the manifest's
+// signature must verify against the pinned Tillitis release key, and its
+// sha512 must equal GetEmbeddedAppDigest(). On success, the embedded app is
+// marked verified and loadApp is allowed to proceed; on any failure it
+// isn't, and the caller should refuse to continue.
+func VerifyEmbeddedApp(ctx context.Context) error {
+	manifest, err := fetchManifest(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch release manifest: %w", err)
+	}
+
+	if err := verifyManifest(manifest); err != nil {
+		return err
+	}
+
+	verifiedManifest = manifest
+	return nil
+}
+
+func manifestURL() string {
+	if url := os.Getenv("TKEY_DEVICE_SIGNER_MANIFEST_URL"); url != "" {
+		return url
+	}
+	return defaultManifestURL
+}
+
+func fetchManifest(ctx context.Context) (*Manifest, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest server returned %d", resp.StatusCode)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+func verifyManifest(manifest *Manifest) error {
+	pinnedKey, err := hex.DecodeString(tillitisReleasePubKeyHex)
+	if err != nil {
+		return fmt.Errorf("invalid pinned release key: %w", err)
+	}
+
+	if manifest.SignerPubkey != tillitisReleasePubKeyHex {
+		return fmt.Errorf("manifest signer_pubkey does not match the pinned Tillitis release key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(manifest.Ed25519Sig)
+	if err != nil {
+		return fmt.Errorf("invalid manifest signature encoding: %w", err)
+	}
+
+	signedData := []byte(manifest.Version + manifest.SHA512)
+	if !ed25519.Verify(pinnedKey, signedData, sig) {
+		return fmt.Errorf("manifest signature does not verify against the pinned release key")
+	}
+
+	localDigest := GetEmbeddedAppDigest()
+	if manifest.SHA512 != localDigest {
+		return fmt.Errorf("embedded app digest %s does not match manifest digest %s for version %s", localDigest, manifest.SHA512, manifest.Version)
+	}
+
+	return nil
+}
+
+// GetVerifiedManifest returns the manifest from the last successful
+// VerifyEmbeddedApp call, for /api/attest to report. ok is false if
+// VerifyEmbeddedApp has never succeeded in this process.
+func GetVerifiedManifest() (manifest *Manifest, ok bool) {
+	if verifiedManifest == nil {
+		return nil, false
+	}
+	return verifiedManifest, true
+}
@@ -0,0 +1,31 @@
+package transport
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// jsonCodecName is registered with grpc's encoding package so GRPCBackend
+// can call grpc.CallContentSubtype(jsonCodecName) instead of requiring
+// protoc-generated, protobuf-tagged message types for what are otherwise
+// plain JSON-shaped requests.
+const jsonCodecName = "json"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return jsonCodecName
+}
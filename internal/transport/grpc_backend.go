@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+)
+
+// GRPCBackend talks to the backend over gRPC, using the JSON codec
+// registered in codec.go rather than protoc-generated stubs, since the
+// request/response shapes here are already plain JSON-tagged structs
+// shared with HTTPBackend.
+type GRPCBackend struct {
+	conn *grpc.ClientConn
+}
+
+// NewGRPCBackend wraps an already-dialed *grpc.ClientConn. Callers own the
+// connection's lifetime (dial options, TLS, retries at the transport level
+// are grpc's own concern here).
+func NewGRPCBackend(conn *grpc.ClientConn) *GRPCBackend {
+	return &GRPCBackend{conn: conn}
+}
+
+func (b *GRPCBackend) invoke(ctx context.Context, method string, req, resp interface{}) error {
+	if err := b.conn.Invoke(ctx, method, req, resp, grpc.CallContentSubtype(jsonCodecName)); err != nil {
+		return fmt.Errorf("transport: grpc call %s failed: %w", method, err)
+	}
+	return nil
+}
+
+func (b *GRPCBackend) Register(ctx context.Context, username, label string, pubkey []byte) error {
+	return b.invoke(ctx, "/transport.Backend/Register", &registerRequest{Username: username, Label: label, Pubkey: pubkey}, &struct{}{})
+}
+
+func (b *GRPCBackend) AddKey(ctx context.Context, username, label string, pubkey []byte, sessionCookie string) error {
+	return b.invoke(ctx, "/transport.Backend/AddKey", &addKeyRequest{Username: username, Label: label, Pubkey: pubkey, SessionCookie: sessionCookie}, &struct{}{})
+}
+
+func (b *GRPCBackend) RemoveKey(ctx context.Context, username, label, sessionCookie string) error {
+	return b.invoke(ctx, "/transport.Backend/RemoveKey", &removeKeyRequest{Username: username, Label: label, SessionCookie: sessionCookie}, &struct{}{})
+}
+
+func (b *GRPCBackend) GetChallenge(ctx context.Context, username string) ([]byte, error) {
+	var resp challengeResponse
+	if err := b.invoke(ctx, "/transport.Backend/GetChallenge", &challengeRequest{Username: username}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Challenge, nil
+}
+
+func (b *GRPCBackend) SubmitSignature(ctx context.Context, username string, signature []byte) error {
+	return b.invoke(ctx, "/transport.Backend/SubmitSignature", &submitSignatureRequest{Username: username, Signature: signature}, &struct{}{})
+}
@@ -0,0 +1,164 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+// HTTPBackend talks to the backend over plain HTTP(S), retrying on 5xx
+// responses and network errors with exponential backoff, similar to how an
+// ACME client retries a POST that raced the server (see retryPostJWS in
+// golang.org/x/crypto/acme).
+type HTTPBackend struct {
+	baseURL    string
+	client     *http.Client
+	maxRetries int
+}
+
+// NewHTTPBackend builds an HTTPBackend against baseURL. If pins is
+// non-empty, the backend's TLS certificate must match one of the given
+// SHA-256 pins (hex-encoded, of the DER-encoded leaf certificate) in
+// addition to passing normal certificate verification.
+func NewHTTPBackend(baseURL string, pins []string) *HTTPBackend {
+	transport := &http.Transport{}
+	if len(pins) > 0 {
+		transport.TLSClientConfig = &tls.Config{
+			VerifyPeerCertificate: verifyPins(pins),
+		}
+	}
+
+	return &HTTPBackend{
+		baseURL:    baseURL,
+		client:     &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		maxRetries: 5,
+	}
+}
+
+// verifyPins returns a tls.Config.VerifyPeerCertificate callback that
+// accepts the connection only if the leaf certificate's SHA-256 digest is
+// in pins.
+func verifyPins(pins []string) func([][]byte, [][]*x509.Certificate) error {
+	pinSet := make(map[string]bool, len(pins))
+	for _, p := range pins {
+		pinSet[p] = true
+	}
+
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("transport: no certificate presented")
+		}
+		leaf := sha256.Sum256(rawCerts[0])
+		digest := fmt.Sprintf("%x", leaf)
+		if !pinSet[digest] {
+			return fmt.Errorf("transport: certificate %s is not in the pinned set", digest)
+		}
+		return nil
+	}
+}
+
+func (b *HTTPBackend) Register(ctx context.Context, username, label string, pubkey []byte) error {
+	return b.do(ctx, http.MethodPost, "/api/register", registerRequest{Username: username, Label: label, Pubkey: pubkey}, nil, "")
+}
+
+func (b *HTTPBackend) AddKey(ctx context.Context, username, label string, pubkey []byte, sessionCookie string) error {
+	return b.do(ctx, http.MethodPost, "/api/add-public-key", addKeyRequest{Username: username, Label: label, Pubkey: pubkey, SessionCookie: sessionCookie}, nil, sessionCookie)
+}
+
+func (b *HTTPBackend) RemoveKey(ctx context.Context, username, label, sessionCookie string) error {
+	return b.do(ctx, http.MethodPost, "/api/remove-public-key", removeKeyRequest{Username: username, Label: label, SessionCookie: sessionCookie}, nil, sessionCookie)
+}
+
+func (b *HTTPBackend) GetChallenge(ctx context.Context, username string) ([]byte, error) {
+	var resp challengeResponse
+	if err := b.do(ctx, http.MethodPost, "/api/challenge", challengeRequest{Username: username}, &resp, ""); err != nil {
+		return nil, err
+	}
+	return resp.Challenge, nil
+}
+
+func (b *HTTPBackend) SubmitSignature(ctx context.Context, username string, signature []byte) error {
+	return b.do(ctx, http.MethodPost, "/api/submit-signature", submitSignatureRequest{Username: username, Signature: signature}, nil, "")
+}
+
+// do sends reqBody as a JSON request, decoding a JSON response into
+// respBody (if non-nil), retrying on 5xx responses and network errors with
+// exponential backoff.
+func (b *HTTPBackend) do(ctx context.Context, method, path string, reqBody interface{}, respBody interface{}, sessionCookie string) error {
+	encoded, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("transport: failed to encode request: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= b.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff(attempt)):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, bytes.NewReader(encoded))
+		if err != nil {
+			return fmt.Errorf("transport: failed to build request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if sessionCookie != "" {
+			req.Header.Set("Cookie", sessionCookie)
+		}
+
+		resp, err := b.client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("transport: request failed: %w", err)
+			continue
+		}
+
+		body, readErr := func() ([]byte, error) {
+			defer resp.Body.Close()
+			return io.ReadAll(resp.Body)
+		}()
+
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("transport: backend returned %d: %s", resp.StatusCode, string(body))
+			continue
+		}
+
+		if readErr != nil {
+			return fmt.Errorf("transport: failed to read response: %w", readErr)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("transport: backend returned %d: %s", resp.StatusCode, string(body))
+		}
+
+		if respBody != nil && len(body) > 0 {
+			if err := json.Unmarshal(body, respBody); err != nil {
+				return fmt.Errorf("transport: failed to decode response: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	return lastErr
+}
+
+// backoff returns an exponential backoff duration for the given attempt
+// number (1-indexed), capped at 10 seconds.
+func backoff(attempt int) time.Duration {
+	d := time.Duration(math.Pow(2, float64(attempt))) * 100 * time.Millisecond
+	if d > 10*time.Second {
+		return 10 * time.Second
+	}
+	return d
+}
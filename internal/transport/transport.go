@@ -0,0 +1,50 @@
+// Package transport abstracts the client's connection to the backend that
+// holds the username/public-key registry, so the HTTP handlers in main
+// depend on a configured endpoint rather than deriving one from whatever
+// Origin header a request happens to carry.
+package transport
+
+import "context"
+
+// Backend is everything the web client needs from the backend.
+type Backend interface {
+	Register(ctx context.Context, username, label string, pubkey []byte) error
+	AddKey(ctx context.Context, username, label string, pubkey []byte, sessionCookie string) error
+	RemoveKey(ctx context.Context, username, label, sessionCookie string) error
+	GetChallenge(ctx context.Context, username string) ([]byte, error)
+	SubmitSignature(ctx context.Context, username string, signature []byte) error
+}
+
+// registerRequest/etc. are the wire messages shared by both the HTTPBackend
+// (as a JSON body) and the GRPCBackend (as a JSON-codec gRPC payload).
+type registerRequest struct {
+	Username string `json:"username"`
+	Label    string `json:"label"`
+	Pubkey   []byte `json:"pubkey"`
+}
+
+type addKeyRequest struct {
+	Username      string `json:"username"`
+	Label         string `json:"label"`
+	Pubkey        []byte `json:"pubkey"`
+	SessionCookie string `json:"sessionCookie"`
+}
+
+type removeKeyRequest struct {
+	Username      string `json:"username"`
+	Label         string `json:"label"`
+	SessionCookie string `json:"sessionCookie"`
+}
+
+type challengeRequest struct {
+	Username string `json:"username"`
+}
+
+type challengeResponse struct {
+	Challenge []byte `json:"challenge"`
+}
+
+type submitSignatureRequest struct {
+	Username  string `json:"username"`
+	Signature []byte `json:"signature"`
+}
@@ -0,0 +1,108 @@
+package webauthn
+
+// A minimal, deterministic CBOR encoder covering just the major types the
+// WebAuthn attestation/assertion structures need (RFC 8949 core deterministic
+// encoding: definite-length items, map keys sorted by encoded length then
+// bytewise). We intentionally don't pull in a general-purpose CBOR library
+// for the handful of fixed-shape maps built here.
+
+import (
+	"encoding/binary"
+)
+
+const (
+	cborMajorUint   = 0 << 5
+	cborMajorNegInt = 1 << 5
+	cborMajorBytes  = 2 << 5
+	cborMajorText   = 3 << 5
+	cborMajorArray  = 4 << 5
+	cborMajorMap    = 5 << 5
+)
+
+func cborEncodeHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major | byte(n)}
+	case n <= 0xff:
+		return []byte{major | 24, byte(n)}
+	case n <= 0xffff:
+		b := make([]byte, 3)
+		b[0] = major | 25
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		return b
+	case n <= 0xffffffff:
+		b := make([]byte, 5)
+		b[0] = major | 26
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		return b
+	default:
+		b := make([]byte, 9)
+		b[0] = major | 27
+		binary.BigEndian.PutUint64(b[1:], n)
+		return b
+	}
+}
+
+func cborUint(v uint64) []byte {
+	return cborEncodeHead(cborMajorUint, v)
+}
+
+// cborNegInt encodes a negative integer. v must be < 0.
+func cborNegInt(v int64) []byte {
+	return cborEncodeHead(cborMajorNegInt, uint64(-1-v))
+}
+
+// cborInt encodes a signed integer using whichever major type applies.
+func cborInt(v int) []byte {
+	if v >= 0 {
+		return cborUint(uint64(v))
+	}
+	return cborNegInt(int64(v))
+}
+
+func cborBytes(b []byte) []byte {
+	out := cborEncodeHead(cborMajorBytes, uint64(len(b)))
+	return append(out, b...)
+}
+
+func cborText(s string) []byte {
+	out := cborEncodeHead(cborMajorText, uint64(len(s)))
+	return append(out, []byte(s)...)
+}
+
+// cborMapPair is one key/value entry of a CBOR map, pre-encoded.
+type cborMapPair struct {
+	key   []byte
+	value []byte
+}
+
+// cborMap encodes a map in canonical order: entries sorted by key length,
+// then bytewise within equal length, as required for CTAP2 canonical CBOR.
+func cborMap(pairs []cborMapPair) []byte {
+	sorted := make([]cborMapPair, len(pairs))
+	copy(sorted, pairs)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && cborKeyLess(sorted[j].key, sorted[j-1].key); j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+
+	out := cborEncodeHead(cborMajorMap, uint64(len(sorted)))
+	for _, p := range sorted {
+		out = append(out, p.key...)
+		out = append(out, p.value...)
+	}
+	return out
+}
+
+func cborKeyLess(a, b []byte) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
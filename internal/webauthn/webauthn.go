@@ -0,0 +1,186 @@
+// Package webauthn adapts the TKey-backed Ed25519 signer to the
+// WebAuthn/CTAP2 authenticator model, so the client can answer a standard
+// navigator.credentials.create()/get() ceremony instead of the bespoke
+// Register/GetAndSign flow in internal/auth.
+package webauthn
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"chalmers/tkey-group22/client/internal/tkey"
+)
+
+// coseAlgEdDSA is the COSE algorithm identifier for Ed25519 (EdDSA), as
+// registered in the IANA COSE Algorithms table.
+const coseAlgEdDSA = -8
+
+// Flags set in authenticator data: user present, user verified and
+// attested-credential-data-included. The TKey requires a physical touch to
+// sign, which we treat as proof of both presence and verification.
+const (
+	flagUP = 1 << 0
+	flagUV = 1 << 2
+	flagAT = 1 << 6
+)
+
+// aaguid identifies "this kind of authenticator" (the tkey-device-signer
+// app), not a specific TKey unit. It has no registered meaning outside this
+// client, so we use an all-zero AAGUID as TKey's upstream tooling does.
+var aaguid = [16]byte{}
+
+// AttestationObject is the result of a MakeCredential (registration)
+// ceremony: the raw authenticator data plus a "packed" self-attestation
+// statement over it.
+type AttestationObject struct {
+	AuthData []byte
+	Sig      []byte
+}
+
+// CBOR encodes the attestation object in the form the WebAuthn spec expects
+// to find at PublicKeyCredential.response.attestationObject: a CBOR map with
+// "fmt", "attStmt" and "authData".
+func (a *AttestationObject) CBOR() []byte {
+	attStmt := cborMap([]cborMapPair{
+		{key: cborText("alg"), value: cborInt(coseAlgEdDSA)},
+		{key: cborText("sig"), value: cborBytes(a.Sig)},
+	})
+
+	return cborMap([]cborMapPair{
+		{key: cborText("fmt"), value: cborText("packed")},
+		{key: cborText("attStmt"), value: attStmt},
+		{key: cborText("authData"), value: cborBytes(a.AuthData)},
+	})
+}
+
+// AssertionResponse is the result of a GetAssertion (login) ceremony.
+type AssertionResponse struct {
+	CredentialID      []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+// credentialID derives a stable WebAuthn credential ID for the TKey's
+// resident key. The TKey has no concept of multiple discoverable
+// credentials, so the ID is deterministically derived from the public key
+// rather than randomly generated and stored.
+func credentialID(pub ed25519.PublicKey) []byte {
+	sum := sha256.Sum256(pub)
+	return sum[:16]
+}
+
+// authenticatorData builds the authData structure shared by both
+// attestation and assertion responses: rpIdHash || flags || signCount
+// [|| attestedCredentialData].
+func authenticatorData(rpID string, flags byte, signCount uint32, attestedCredData []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+
+	data := make([]byte, 0, 32+1+4+len(attestedCredData))
+	data = append(data, rpIDHash[:]...)
+	data = append(data, flags)
+	data = append(data, byte(signCount>>24), byte(signCount>>16), byte(signCount>>8), byte(signCount))
+	data = append(data, attestedCredData...)
+	return data
+}
+
+// MakeCredential runs a WebAuthn registration ceremony against the TKey,
+// producing an attestation object for the given relying party.
+//
+// challenge must be the SHA-256 hash of the clientDataJSON the browser
+// produced for this ceremony (i.e. the clientDataHash), not the raw
+// challenge bytes — the packed attestation signature is made over
+// authData || clientDataHash.
+func MakeCredential(rpID string, userHandle, challenge []byte, pubKeyCredParams []int) (*AttestationObject, error) {
+	if !containsAlg(pubKeyCredParams, coseAlgEdDSA) {
+		return nil, errors.New("webauthn: relying party did not offer Ed25519 (alg -8), which is all the TKey supports")
+	}
+
+	pub, err := tkey.GetTkeyPubKey()
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: get public key: %w", err)
+	}
+
+	credID := credentialID(pub)
+	coseKey := encodeCOSEKeyEd25519(pub)
+
+	attestedCredData := make([]byte, 0, 16+2+len(credID)+len(coseKey))
+	attestedCredData = append(attestedCredData, aaguid[:]...)
+	attestedCredData = append(attestedCredData, byte(len(credID)>>8), byte(len(credID)))
+	attestedCredData = append(attestedCredData, credID...)
+	attestedCredData = append(attestedCredData, coseKey...)
+
+	authData := authenticatorData(rpID, flagUP|flagUV|flagAT, 0, attestedCredData)
+
+	sig, err := tkey.Sign(append(append([]byte{}, authData...), challenge...))
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: sign attestation: %w", err)
+	}
+
+	_ = userHandle // carried by the caller to associate the credential with a user, not needed in authData
+
+	return &AttestationObject{AuthData: authData, Sig: sig}, nil
+}
+
+// GetAssertion runs a WebAuthn authentication ceremony against the TKey.
+//
+// challenge must be the clientDataHash, for the same reason as in
+// MakeCredential. allowCredIDs, if non-empty, restricts which credential IDs
+// the relying party will accept; since the TKey only ever has one resident
+// credential, a list that does not contain that one results in an error.
+func GetAssertion(rpID string, challenge []byte, allowCredIDs [][]byte) (*AssertionResponse, error) {
+	pub, err := tkey.GetTkeyPubKey()
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: get public key: %w", err)
+	}
+
+	credID := credentialID(pub)
+	if len(allowCredIDs) > 0 && !containsCredID(allowCredIDs, credID) {
+		return nil, errors.New("webauthn: no allowed credential matches the connected TKey")
+	}
+
+	authData := authenticatorData(rpID, flagUP|flagUV, 0, nil)
+
+	sig, err := tkey.Sign(append(append([]byte{}, authData...), challenge...))
+	if err != nil {
+		return nil, fmt.Errorf("webauthn: sign assertion: %w", err)
+	}
+
+	return &AssertionResponse{
+		CredentialID:      credID,
+		AuthenticatorData: authData,
+		Signature:         sig,
+		UserHandle:        pub,
+	}, nil
+}
+
+// encodeCOSEKeyEd25519 encodes a COSE_Key for an OKP Ed25519 public key:
+// {1: 1 (kty=OKP), 3: -8 (alg=EdDSA), -1: 6 (crv=Ed25519), -2: x}.
+func encodeCOSEKeyEd25519(pub ed25519.PublicKey) []byte {
+	return cborMap([]cborMapPair{
+		{key: cborInt(1), value: cborInt(1)},
+		{key: cborInt(3), value: cborInt(coseAlgEdDSA)},
+		{key: cborInt(-1), value: cborInt(6)},
+		{key: cborInt(-2), value: cborBytes(pub)},
+	})
+}
+
+func containsAlg(algs []int, want int) bool {
+	for _, a := range algs {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
+
+func containsCredID(ids [][]byte, want []byte) bool {
+	for _, id := range ids {
+		if string(id) == string(want) {
+			return true
+		}
+	}
+	return false
+}